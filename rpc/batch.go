@@ -0,0 +1,37 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// BatchRequest describes a single JSON-RPC call to be issued as part of a
+// batch.
+type BatchRequest struct {
+	Method string
+	Args   []interface{}
+}
+
+// BatchResponse holds the raw, still-encoded result of a single call within a
+// batch, along with any per-call error returned by the server. Result is left
+// as json.RawMessage rather than unmarshaled into a concrete type because
+// BatchCaller has no way to know what Go type each request's response should
+// decode into; callers unmarshal Result themselves once BatchCall returns.
+type BatchResponse struct {
+	Result json.RawMessage
+	Error  error
+}
+
+// BatchCaller is implemented by Callers that can pipeline multiple JSON-RPC
+// requests over a single connection, rather than issuing them one at a time.
+// This package provides no implementation of it: BatchCall takes this
+// package's own BatchRequest type, so a wsrpc client does not satisfy the
+// interface by itself. Code using BatchCall should fall back to issuing calls
+// individually when a Caller doesn't implement this interface.
+type BatchCaller interface {
+	BatchCall(ctx context.Context, reqs []BatchRequest) ([]BatchResponse, error)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/jrick/bitset"
+)
+
+// ExistsLiveTickets uses the existslivetickets RPC to check which of the
+// provided ticket hashes are live tickets known to the dcrd instance. The
+// result slice is in the same order as ticketHashes. This packs all of the
+// hashes into a single RPC call instead of issuing one call per hash, which
+// matters when vspd revalidates thousands of tickets on startup or after a
+// reorg.
+func (c *DcrdRPC) ExistsLiveTickets(ticketHashes []string) ([]bool, error) {
+	if len(ticketHashes) == 0 {
+		return nil, nil
+	}
+
+	var exists string
+	err := c.Call(c.ctx, "existslivetickets", &exists, ticketHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	existsBytes := make([]byte, hex.DecodedLen(len(exists)))
+	_, err = hex.Decode(existsBytes, []byte(exists))
+	if err != nil {
+		return nil, err
+	}
+
+	bs := bitset.Bytes(existsBytes)
+	results := make([]bool, len(ticketHashes))
+	for i := range ticketHashes {
+		results[i] = bs.Get(i)
+	}
+
+	return results, nil
+}
+
+// GetRawTransactions uses the getrawtransaction RPC to retrieve details about
+// each of the provided transaction hashes. Unlike existslivetickets, dcrd has
+// no form of getrawtransaction that accepts multiple hashes in one call, so
+// this instead pipelines one getrawtransaction request per hash over a
+// single websocket connection when the underlying Caller supports batching,
+// falling back to issuing the calls one at a time otherwise.
+func (c *DcrdRPC) GetRawTransactions(txHashes []string) ([]*dcrdtypes.TxRawResult, error) {
+	if len(txHashes) == 0 {
+		return nil, nil
+	}
+
+	batcher, ok := c.Caller.(BatchCaller)
+	if !ok {
+		results := make([]*dcrdtypes.TxRawResult, len(txHashes))
+		for i, h := range txHashes {
+			tx, err := c.GetRawTransaction(h)
+			if err != nil {
+				return nil, fmt.Errorf("getrawtransaction for %s failed: %w", h, err)
+			}
+			results[i] = tx
+		}
+		return results, nil
+	}
+
+	verbose := 1
+	reqs := make([]BatchRequest, len(txHashes))
+	for i, h := range txHashes {
+		reqs[i] = BatchRequest{Method: "getrawtransaction", Args: []interface{}{h, verbose}}
+	}
+
+	resps, err := batcher.BatchCall(c.ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	if len(resps) != len(txHashes) {
+		return nil, fmt.Errorf("expected %d batch responses, got %d", len(txHashes), len(resps))
+	}
+
+	results := make([]*dcrdtypes.TxRawResult, len(txHashes))
+	for i, resp := range resps {
+		if resp.Error != nil {
+			return nil, fmt.Errorf("getrawtransaction for %s failed: %w", txHashes[i], resp.Error)
+		}
+		var tx dcrdtypes.TxRawResult
+		if err := json.Unmarshal(resp.Result, &tx); err != nil {
+			return nil, fmt.Errorf("decode getrawtransaction result for %s: %w", txHashes[i], err)
+		}
+		results[i] = &tx
+	}
+
+	return results, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+)
+
+// stubExistsCaller answers existslivetickets with a fixed hex-encoded bitset,
+// matching the shape c.Call is expected to populate through its result
+// pointer.
+type stubExistsCaller struct {
+	existsHex string
+}
+
+func (s stubExistsCaller) Call(ctx context.Context, method string, res interface{}, args ...interface{}) error {
+	switch method {
+	case "existslivetickets":
+		*res.(*string) = s.existsHex
+	}
+	return nil
+}
+
+func TestExistsLiveTicketsDecodesBitsetInOrder(t *testing.T) {
+	// Bit 0 clear, bits 1 and 2 set: hash[0] not live, hash[1] and hash[2]
+	// live.
+	c := &DcrdRPC{Caller: stubExistsCaller{existsHex: "06"}, ctx: context.Background()}
+
+	got, err := c.ExistsLiveTickets([]string{"hash0", "hash1", "hash2"})
+	if err != nil {
+		t.Fatalf("ExistsLiveTickets() returned error: %v", err)
+	}
+
+	want := []bool{false, true, true}
+	if len(got) != len(want) {
+		t.Fatalf("ExistsLiveTickets() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ExistsLiveTickets()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExistsLiveTicketsEmptyInput(t *testing.T) {
+	c := &DcrdRPC{Caller: stubExistsCaller{}, ctx: context.Background()}
+
+	got, err := c.ExistsLiveTickets(nil)
+	if err != nil {
+		t.Fatalf("ExistsLiveTickets() returned error: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("ExistsLiveTickets(nil) = %v, want nil", got)
+	}
+}
+
+// stubBatchCaller implements both Caller and BatchCaller, answering
+// getrawtransaction batch requests with canned per-hash results.
+type stubBatchCaller struct {
+	results map[string]dcrdtypes.TxRawResult
+}
+
+func (s stubBatchCaller) Call(ctx context.Context, method string, res interface{}, args ...interface{}) error {
+	return nil
+}
+
+func (s stubBatchCaller) BatchCall(ctx context.Context, reqs []BatchRequest) ([]BatchResponse, error) {
+	resps := make([]BatchResponse, len(reqs))
+	for i, req := range reqs {
+		hash := req.Args[0].(string)
+		body, err := json.Marshal(s.results[hash])
+		if err != nil {
+			return nil, err
+		}
+		resps[i] = BatchResponse{Result: body}
+	}
+	return resps, nil
+}
+
+func TestGetRawTransactionsDecodesBatchResults(t *testing.T) {
+	c := &DcrdRPC{
+		Caller: stubBatchCaller{
+			results: map[string]dcrdtypes.TxRawResult{
+				"hash0": {Txid: "hash0", Confirmations: 1},
+				"hash1": {Txid: "hash1", Confirmations: 2},
+			},
+		},
+		ctx: context.Background(),
+	}
+
+	got, err := c.GetRawTransactions([]string{"hash0", "hash1"})
+	if err != nil {
+		t.Fatalf("GetRawTransactions() returned error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetRawTransactions() returned %d results, want 2", len(got))
+	}
+	if got[0].Txid != "hash0" || got[0].Confirmations != 1 {
+		t.Fatalf("GetRawTransactions()[0] = %+v, want Txid hash0, Confirmations 1", got[0])
+	}
+	if got[1].Txid != "hash1" || got[1].Confirmations != 2 {
+		t.Fatalf("GetRawTransactions()[1] = %+v, want Txid hash1, Confirmations 2", got[1])
+	}
+}
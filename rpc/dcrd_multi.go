@@ -0,0 +1,361 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/jrick/wsrpc/v2"
+)
+
+// DcrdEndpoint describes a single dcrd JSON-RPC endpoint making up part of a
+// MultiDcrdConnect pool.
+type DcrdEndpoint struct {
+	User string
+	Pass string
+	Addr string
+	Cert []byte
+}
+
+// backendStatus tracks connectivity and performance stats for a single
+// backend in a MultiDcrdConnect pool.
+type backendStatus struct {
+	mu       sync.Mutex
+	addr     string
+	healthy  bool
+	height   int64
+	latency  time.Duration
+	errCount int64
+	lastErr  error
+}
+
+// BackendStatus is a snapshot of a single backend's health, returned by
+// MultiDcrdRPC.Status.
+type BackendStatus struct {
+	Addr     string
+	Healthy  bool
+	Height   int64
+	Latency  time.Duration
+	ErrCount int64
+	LastErr  error
+}
+
+func (s *backendStatus) snapshot() BackendStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return BackendStatus{
+		Addr:     s.addr,
+		Healthy:  s.healthy,
+		Height:   s.height,
+		Latency:  s.latency,
+		ErrCount: s.errCount,
+		LastErr:  s.lastErr,
+	}
+}
+
+func (s *backendStatus) recordSuccess(height int64, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = true
+	s.height = height
+	s.latency = latency
+	s.lastErr = nil
+}
+
+func (s *backendStatus) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = false
+	s.errCount++
+	s.lastErr = err
+}
+
+// MultiDcrdConnect maintains a pool of DcrdConnect instances spread across
+// multiple dcrd endpoints, so that a single unreachable or stale-tipped node
+// does not take vspd down with it.
+type MultiDcrdConnect struct {
+	conns  []DcrdConnect
+	status []*backendStatus
+}
+
+// SetupMultiDcrd initializes a pool of dcrd connections, one per endpoint.
+func SetupMultiDcrd(endpoints []DcrdEndpoint, n wsrpc.Notifier) *MultiDcrdConnect {
+	m := &MultiDcrdConnect{
+		conns:  make([]DcrdConnect, len(endpoints)),
+		status: make([]*backendStatus, len(endpoints)),
+	}
+	for i, e := range endpoints {
+		m.conns[i] = SetupDcrd(e.User, e.Pass, e.Addr, e.Cert, n)
+		m.status[i] = &backendStatus{addr: e.Addr}
+	}
+	return m
+}
+
+// MultiDcrdRPC is a DcrdRPC-compatible client backed by a pool of dcrd
+// connections. Idempotent calls are tried against backends in order()'s
+// preference order and move on to the next backend after any error, with
+// unhealthy backends tried last. A stale tip is not detected by the call
+// itself: a backend with a lower recorded height is only ranked lower, and a
+// call that succeeds against it is not retried elsewhere.
+type MultiDcrdRPC struct {
+	pool    *MultiDcrdConnect
+	clients []*DcrdRPC
+	ctx     context.Context
+}
+
+// Client dials every backend in the pool (reusing any existing connections)
+// and returns a MultiDcrdRPC. It succeeds as long as at least one backend is
+// reachable.
+func (m *MultiDcrdConnect) Client(ctx context.Context, netParams *chaincfg.Params) (*MultiDcrdRPC, error) {
+	clients := make([]*DcrdRPC, len(m.conns))
+	var lastErr error
+	healthy := 0
+	for i := range m.conns {
+		start := time.Now()
+		c, err := m.conns[i].Client(ctx, netParams)
+		if err != nil {
+			m.status[i].recordError(err)
+			lastErr = err
+			continue
+		}
+		clients[i] = c
+		healthy++
+
+		header, err := c.GetBestBlockHeader()
+		if err != nil {
+			m.status[i].recordError(err)
+			continue
+		}
+		m.status[i].recordSuccess(int64(header.Height), time.Since(start))
+	}
+	if healthy == 0 {
+		return nil, fmt.Errorf("no healthy dcrd backends available: %w", lastErr)
+	}
+
+	return &MultiDcrdRPC{pool: m, clients: clients, ctx: ctx}, nil
+}
+
+// order returns the index of backends ordered by preference: healthy
+// backends first, highest reported block height first.
+func (m *MultiDcrdRPC) order() []int {
+	idx := make([]int, 0, len(m.clients))
+	for i, c := range m.clients {
+		if c != nil {
+			idx = append(idx, i)
+		}
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		sa, sb := m.pool.status[idx[a]].snapshot(), m.pool.status[idx[b]].snapshot()
+		if sa.Healthy != sb.Healthy {
+			return sa.Healthy
+		}
+		return sa.Height > sb.Height
+	})
+	return idx
+}
+
+// retry calls fn against backends in preference order, moving on to the next
+// backend on error, until one succeeds or all have been tried.
+func (m *MultiDcrdRPC) retry(fn func(c *DcrdRPC) error) error {
+	var lastErr error
+	for _, i := range m.order() {
+		start := time.Now()
+		err := fn(m.clients[i])
+		if err != nil {
+			m.pool.status[i].recordError(err)
+			lastErr = err
+			continue
+		}
+		m.pool.status[i].latencyOnly(time.Since(start))
+		return nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no dcrd backends configured")
+	}
+	return lastErr
+}
+
+func (s *backendStatus) latencyOnly(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// GetRawTransaction retries getrawtransaction against backends in preference
+// order until one succeeds.
+func (m *MultiDcrdRPC) GetRawTransaction(txHash string) (*dcrdtypes.TxRawResult, error) {
+	var resp *dcrdtypes.TxRawResult
+	err := m.retry(func(c *DcrdRPC) error {
+		r, err := c.GetRawTransaction(txHash)
+		if err != nil {
+			return err
+		}
+		resp = r
+		return nil
+	})
+	return resp, err
+}
+
+// SendRawTransaction broadcasts the transaction to every reachable backend in
+// parallel, to reduce propagation latency and reliance on a single node.
+// Duplicate-tx errors are swallowed, matching DcrdRPC.SendRawTransaction.
+func (m *MultiDcrdRPC) SendRawTransaction(txHex string) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(m.clients))
+	for i, c := range m.clients {
+		if c == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, c *DcrdRPC) {
+			defer wg.Done()
+			if err := c.SendRawTransaction(txHex); err != nil {
+				m.pool.status[i].recordError(err)
+				errs[i] = err
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	// The broadcast only fails if every reachable backend rejected it.
+	var lastErr error
+	succeeded := false
+	for i, c := range m.clients {
+		if c == nil {
+			continue
+		}
+		if errs[i] == nil {
+			succeeded = true
+			continue
+		}
+		lastErr = errs[i]
+	}
+	if !succeeded {
+		return lastErr
+	}
+	return nil
+}
+
+// NotifyBlocks requests new block notifications from every reachable
+// backend.
+func (m *MultiDcrdRPC) NotifyBlocks() error {
+	var lastErr error
+	notified := false
+	for i, c := range m.clients {
+		if c == nil {
+			continue
+		}
+		if err := c.NotifyBlocks(); err != nil {
+			m.pool.status[i].recordError(err)
+			lastErr = err
+			continue
+		}
+		notified = true
+	}
+	if !notified {
+		return lastErr
+	}
+	return nil
+}
+
+// GetBestBlockHeader queries every reachable backend for its best block
+// header in parallel, updating each backend's recorded height and latency as
+// it goes, and returns the header reported by the backend with the highest
+// height. Querying every backend (rather than just the top-ranked one) keeps
+// order()'s height-based preference accurate for the life of the
+// MultiDcrdRPC instead of reflecting only a one-time snapshot taken at pool
+// construction, and means a backend that has fallen behind is detected here
+// rather than trusted until it happens to error.
+func (m *MultiDcrdRPC) GetBestBlockHeader() (*dcrdtypes.GetBlockHeaderVerboseResult, error) {
+	headers := make([]*dcrdtypes.GetBlockHeaderVerboseResult, len(m.clients))
+	errs := make([]error, len(m.clients))
+
+	var wg sync.WaitGroup
+	for i, c := range m.clients {
+		if c == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, c *DcrdRPC) {
+			defer wg.Done()
+			start := time.Now()
+			header, err := c.GetBestBlockHeader()
+			if err != nil {
+				m.pool.status[i].recordError(err)
+				errs[i] = err
+				return
+			}
+			m.pool.status[i].recordSuccess(int64(header.Height), time.Since(start))
+			headers[i] = header
+		}(i, c)
+	}
+	wg.Wait()
+
+	var best *dcrdtypes.GetBlockHeaderVerboseResult
+	var lastErr error
+	for i, h := range headers {
+		if h == nil {
+			lastErr = errs[i]
+			continue
+		}
+		if best == nil || h.Height > best.Height {
+			best = h
+		}
+	}
+	if best == nil {
+		if lastErr == nil {
+			lastErr = errors.New("no dcrd backends configured")
+		}
+		return nil, lastErr
+	}
+	return best, nil
+}
+
+// ExistsLiveTicket retries existslivetickets against backends in preference
+// order until one succeeds.
+func (m *MultiDcrdRPC) ExistsLiveTicket(ticketHash string) (bool, error) {
+	var exists bool
+	err := m.retry(func(c *DcrdRPC) error {
+		e, err := c.ExistsLiveTicket(ticketHash)
+		if err != nil {
+			return err
+		}
+		exists = e
+		return nil
+	})
+	return exists, err
+}
+
+// CanTicketVote retries CanTicketVote against backends in preference order
+// until one succeeds.
+func (m *MultiDcrdRPC) CanTicketVote(rawTx *dcrdtypes.TxRawResult, ticketHash string, netParams *chaincfg.Params) (bool, error) {
+	var can bool
+	err := m.retry(func(c *DcrdRPC) error {
+		v, err := c.CanTicketVote(rawTx, ticketHash, netParams)
+		if err != nil {
+			return err
+		}
+		can = v
+		return nil
+	})
+	return can, err
+}
+
+// Status returns a health snapshot of every backend in the pool, suitable for
+// surfacing via the admin UI.
+func (m *MultiDcrdRPC) Status() []BackendStatus {
+	out := make([]BackendStatus, len(m.pool.status))
+	for i, s := range m.pool.status {
+		out[i] = s.snapshot()
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// stubCaller is a Caller that never actually issues an RPC; tests exercising
+// order()/retry() supply their own fn to MultiDcrdRPC.retry and never need
+// the underlying *DcrdRPC to do real work.
+type stubCaller struct{}
+
+func (stubCaller) Call(ctx context.Context, method string, res interface{}, args ...interface{}) error {
+	return nil
+}
+
+func newTestMultiRPC(n int) (*MultiDcrdRPC, []*backendStatus) {
+	pool := &MultiDcrdConnect{
+		conns:  make([]DcrdConnect, n),
+		status: make([]*backendStatus, n),
+	}
+	clients := make([]*DcrdRPC, n)
+	for i := 0; i < n; i++ {
+		pool.status[i] = &backendStatus{addr: fmt.Sprintf("backend-%d", i)}
+		clients[i] = &DcrdRPC{Caller: stubCaller{}, ctx: context.Background()}
+	}
+	return &MultiDcrdRPC{pool: pool, clients: clients, ctx: context.Background()}, pool.status
+}
+
+func TestOrderPrefersHealthyThenHighestHeight(t *testing.T) {
+	m, status := newTestMultiRPC(3)
+	status[0].recordSuccess(100, time.Millisecond)
+	status[1].recordSuccess(200, time.Millisecond)
+	status[2].recordError(errors.New("boom"))
+
+	got := m.order()
+	want := []int{1, 0, 2}
+	if len(got) != len(want) {
+		t.Fatalf("order() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRetryFailsOverToNextBackend(t *testing.T) {
+	m, status := newTestMultiRPC(2)
+	status[0].recordSuccess(100, time.Millisecond)
+	status[1].recordSuccess(200, time.Millisecond)
+
+	var calls int
+	err := m.retry(func(c *DcrdRPC) error {
+		calls++
+		// Preference order is backend 1 (higher height) then backend 0, so
+		// the first call made is expected to fail and the second to succeed.
+		if calls == 1 {
+			return errors.New("unreachable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("retry() returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("retry() called fn %d times, want 2", calls)
+	}
+}
+
+func TestRetryReturnsLastErrorWhenAllBackendsFail(t *testing.T) {
+	m, _ := newTestMultiRPC(2)
+
+	want := errors.New("boom")
+	err := m.retry(func(c *DcrdRPC) error {
+		return want
+	})
+	if !errors.Is(err, want) {
+		t.Fatalf("retry() = %v, want %v", err, want)
+	}
+}
+
+func TestRetryNoBackendsConfigured(t *testing.T) {
+	m, _ := newTestMultiRPC(0)
+
+	err := m.retry(func(c *DcrdRPC) error {
+		t.Fatal("fn should not be called with no backends")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("retry() = nil, want error")
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package notify decodes dcrd's JSON-RPC websocket notifications into typed
+// events and fans them out to interested subscribers, so the rest of vspd
+// can react to a managed ticket voting, missing, or being revoked without
+// polling dcrd.
+package notify
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/decred/dcrd/wire"
+)
+
+// EventType identifies the kind of dcrd notification an Event carries.
+type EventType string
+
+const (
+	// BlockConnected fires when a new block extends the main chain.
+	BlockConnected EventType = "blockconnected"
+	// BlockDisconnected fires when a block is removed from the main chain
+	// during a reorg.
+	BlockDisconnected EventType = "blockdisconnected"
+	// SpentAndMissedTickets fires when tickets vote or miss in a connected
+	// block.
+	SpentAndMissedTickets EventType = "spentandmissedtickets"
+	// NewTickets fires when new tickets mature into the live ticket pool.
+	NewTickets EventType = "newtickets"
+)
+
+// BlockEvent is the payload of a BlockConnected or BlockDisconnected event.
+type BlockEvent struct {
+	BlockHash   string
+	BlockHeight int64
+}
+
+// TicketStatusEvent is the payload of a SpentAndMissedTickets event.
+type TicketStatusEvent struct {
+	BlockHash   string
+	BlockHeight int64
+	// Tickets maps ticket hash to its new status, "spent" (voted) or
+	// "missed".
+	Tickets map[string]string
+}
+
+// NewTicketsEvent is the payload of a NewTickets event.
+type NewTicketsEvent struct {
+	BlockHash   string
+	BlockHeight int64
+	// Tickets is the set of ticket hashes that matured into the live ticket
+	// pool in this block.
+	Tickets []string
+}
+
+// Event is a single decoded dcrd notification, ready for dispatch to
+// subscribers.
+type Event struct {
+	Type       EventType
+	Block      *BlockEvent
+	Ticket     *TicketStatusEvent
+	NewTickets *NewTicketsEvent
+}
+
+// Handler is called with each Event a subscriber has subscribed to.
+type Handler func(Event)
+
+// Bus decodes raw dcrd notifications and dispatches the resulting typed
+// events to subscribed handlers. It is safe for concurrent use.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[EventType][]Handler
+}
+
+// New creates an empty notification Bus.
+func New() *Bus {
+	return &Bus{
+		handlers: make(map[EventType][]Handler),
+	}
+}
+
+// Subscribe registers fn to be called whenever an event of the given type is
+// dispatched. Subscribers are called synchronously and in registration
+// order, so slow subscribers (e.g. a webhook POST) should hand off to a
+// goroutine themselves rather than block the dispatch loop.
+func (b *Bus) Subscribe(t EventType, fn Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[t] = append(b.handlers[t], fn)
+}
+
+// HandleNotification decodes a raw dcrd websocket notification and
+// dispatches it to any subscribed handlers. It is intended to be called from
+// the wsrpc.Notifier callback passed to rpc.SetupDcrd; unrecognized methods
+// are ignored so the caller can wire this in alongside other notification
+// handling without filtering first.
+func (b *Bus) HandleNotification(method string, params json.RawMessage) error {
+	switch EventType(method) {
+	case BlockConnected, BlockDisconnected:
+		// dcrd sends these notifications as a positional params array whose
+		// first element is the wire-serialized block header, hex encoded -
+		// not a JSON object, so it must be deserialized as a wire.BlockHeader
+		// rather than json.Unmarshaled.
+		var raw []json.RawMessage
+		if err := json.Unmarshal(params, &raw); err != nil || len(raw) == 0 {
+			return fmt.Errorf("notify: decode %s: %w", method, err)
+		}
+
+		var headerHex string
+		if err := json.Unmarshal(raw[0], &headerHex); err != nil {
+			return fmt.Errorf("notify: decode %s header: %w", method, err)
+		}
+		headerBytes, err := hex.DecodeString(headerHex)
+		if err != nil {
+			return fmt.Errorf("notify: decode %s header hex: %w", method, err)
+		}
+		var header wire.BlockHeader
+		if err := header.Deserialize(bytes.NewReader(headerBytes)); err != nil {
+			return fmt.Errorf("notify: deserialize %s header: %w", method, err)
+		}
+
+		b.dispatch(Event{
+			Type:  EventType(method),
+			Block: &BlockEvent{BlockHash: header.BlockHash().String(), BlockHeight: int64(header.Height)},
+		})
+
+	case SpentAndMissedTickets:
+		// dcrd sends this as a positional params array: block hash, block
+		// height, stake difficulty, then a map of ticket hash to its new
+		// status ("spent" or "missed") - not a single JSON object.
+		var raw []json.RawMessage
+		if err := json.Unmarshal(params, &raw); err != nil || len(raw) < 4 {
+			return fmt.Errorf("notify: decode %s: %w", method, err)
+		}
+
+		var blockHash string
+		if err := json.Unmarshal(raw[0], &blockHash); err != nil {
+			return fmt.Errorf("notify: decode %s block hash: %w", method, err)
+		}
+		var blockHeight int64
+		if err := json.Unmarshal(raw[1], &blockHeight); err != nil {
+			return fmt.Errorf("notify: decode %s block height: %w", method, err)
+		}
+		var tickets map[string]string
+		if err := json.Unmarshal(raw[3], &tickets); err != nil {
+			return fmt.Errorf("notify: decode %s tickets: %w", method, err)
+		}
+
+		b.dispatch(Event{
+			Type: EventType(method),
+			Ticket: &TicketStatusEvent{
+				BlockHash:   blockHash,
+				BlockHeight: blockHeight,
+				Tickets:     tickets,
+			},
+		})
+
+	case NewTickets:
+		// dcrd sends this as a positional params array too, but the last
+		// element is a plain array of ticket hashes that matured in this
+		// block, not a map - there's one block hash for the whole
+		// notification, not a per-ticket value.
+		var raw []json.RawMessage
+		if err := json.Unmarshal(params, &raw); err != nil || len(raw) < 4 {
+			return fmt.Errorf("notify: decode %s: %w", method, err)
+		}
+
+		var blockHash string
+		if err := json.Unmarshal(raw[0], &blockHash); err != nil {
+			return fmt.Errorf("notify: decode %s block hash: %w", method, err)
+		}
+		var blockHeight int64
+		if err := json.Unmarshal(raw[1], &blockHeight); err != nil {
+			return fmt.Errorf("notify: decode %s block height: %w", method, err)
+		}
+		var tickets []string
+		if err := json.Unmarshal(raw[3], &tickets); err != nil {
+			return fmt.Errorf("notify: decode %s tickets: %w", method, err)
+		}
+
+		b.dispatch(Event{
+			Type: EventType(method),
+			NewTickets: &NewTicketsEvent{
+				BlockHash:   blockHash,
+				BlockHeight: blockHeight,
+				Tickets:     tickets,
+			},
+		})
+	}
+
+	return nil
+}
+
+func (b *Bus) dispatch(e Event) {
+	b.mu.RLock()
+	handlers := b.handlers[e.Type]
+	b.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(e)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/decred/dcrd/wire"
+)
+
+func encodeHeader(t *testing.T, header *wire.BlockHeader) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := header.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize() returned error: %v", err)
+	}
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func TestHandleNotificationBlockConnected(t *testing.T) {
+	header := &wire.BlockHeader{Height: 12345}
+	headerHex := encodeHeader(t, header)
+
+	params, err := json.Marshal([]interface{}{headerHex, []string{}})
+	if err != nil {
+		t.Fatalf("marshal params: %v", err)
+	}
+
+	var got Event
+	bus := New()
+	bus.Subscribe(BlockConnected, func(e Event) { got = e })
+
+	if err := bus.HandleNotification("blockconnected", params); err != nil {
+		t.Fatalf("HandleNotification() returned error: %v", err)
+	}
+
+	if got.Block == nil {
+		t.Fatal("expected a Block event")
+	}
+	if got.Block.BlockHeight != 12345 {
+		t.Fatalf("BlockHeight = %d, want 12345", got.Block.BlockHeight)
+	}
+	if got.Block.BlockHash != header.BlockHash().String() {
+		t.Fatalf("BlockHash = %s, want %s", got.Block.BlockHash, header.BlockHash().String())
+	}
+}
+
+func TestHandleNotificationBlockDisconnected(t *testing.T) {
+	header := &wire.BlockHeader{Height: 777}
+	headerHex := encodeHeader(t, header)
+
+	params, err := json.Marshal([]interface{}{headerHex})
+	if err != nil {
+		t.Fatalf("marshal params: %v", err)
+	}
+
+	var got Event
+	bus := New()
+	bus.Subscribe(BlockDisconnected, func(e Event) { got = e })
+
+	if err := bus.HandleNotification("blockdisconnected", params); err != nil {
+		t.Fatalf("HandleNotification() returned error: %v", err)
+	}
+	if got.Block == nil || got.Block.BlockHeight != 777 {
+		t.Fatalf("got %+v, want height 777", got.Block)
+	}
+}
+
+func TestHandleNotificationSpentAndMissedTickets(t *testing.T) {
+	params, err := json.Marshal([]interface{}{
+		"00000000000000000000000000000000000000000000000000000000001234",
+		int64(500),
+		200000000,
+		map[string]string{"ticket1": "spent"},
+	})
+	if err != nil {
+		t.Fatalf("marshal params: %v", err)
+	}
+
+	var got Event
+	bus := New()
+	bus.Subscribe(SpentAndMissedTickets, func(e Event) { got = e })
+
+	if err := bus.HandleNotification("spentandmissedtickets", params); err != nil {
+		t.Fatalf("HandleNotification() returned error: %v", err)
+	}
+	if got.Ticket == nil {
+		t.Fatal("expected a Ticket event")
+	}
+	if got.Ticket.BlockHeight != 500 {
+		t.Fatalf("BlockHeight = %d, want 500", got.Ticket.BlockHeight)
+	}
+	if got.Ticket.Tickets["ticket1"] != "spent" {
+		t.Fatalf(`Tickets["ticket1"] = %s, want spent`, got.Ticket.Tickets["ticket1"])
+	}
+}
+
+func TestHandleNotificationNewTickets(t *testing.T) {
+	params, err := json.Marshal([]interface{}{
+		"00000000000000000000000000000000000000000000000000000000005678",
+		int64(600),
+		200000000,
+		[]string{"ticket1", "ticket2"},
+	})
+	if err != nil {
+		t.Fatalf("marshal params: %v", err)
+	}
+
+	var got Event
+	bus := New()
+	bus.Subscribe(NewTickets, func(e Event) { got = e })
+
+	if err := bus.HandleNotification("newtickets", params); err != nil {
+		t.Fatalf("HandleNotification() returned error: %v", err)
+	}
+	if got.NewTickets == nil {
+		t.Fatal("expected a NewTickets event")
+	}
+	if got.NewTickets.BlockHeight != 600 {
+		t.Fatalf("BlockHeight = %d, want 600", got.NewTickets.BlockHeight)
+	}
+	want := []string{"ticket1", "ticket2"}
+	if len(got.NewTickets.Tickets) != len(want) {
+		t.Fatalf("Tickets = %v, want %v", got.NewTickets.Tickets, want)
+	}
+	for i := range want {
+		if got.NewTickets.Tickets[i] != want[i] {
+			t.Fatalf("Tickets = %v, want %v", got.NewTickets.Tickets, want)
+		}
+	}
+}
+
+func TestHandleNotificationUnknownMethodIgnored(t *testing.T) {
+	bus := New()
+	if err := bus.HandleNotification("somethingelse", json.RawMessage(`[]`)); err != nil {
+		t.Fatalf("HandleNotification() returned error: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// SSEHub fans out events to connected HTTP clients using server-sent events.
+// It is registered with a Bus like any other subscriber (see Handler) and
+// additionally implements http.Handler so it can be mounted directly on the
+// admin UI's mux.
+type SSEHub struct {
+	mu      sync.Mutex
+	clients map[chan Event]struct{}
+}
+
+// NewSSEHub creates an empty SSEHub.
+func NewSSEHub() *SSEHub {
+	return &SSEHub{
+		clients: make(map[chan Event]struct{}),
+	}
+}
+
+// Handler returns a Handler suitable for Bus.Subscribe that broadcasts each
+// event to all currently connected SSE clients.
+func (h *SSEHub) Handler() Handler {
+	return func(e Event) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for ch := range h.clients {
+			select {
+			case ch <- e:
+			default:
+				// Slow client; drop the event rather than block dispatch
+				// for every other subscriber.
+			}
+		}
+	}
+}
+
+// ServeHTTP streams events to the client as server-sent events until the
+// request is cancelled.
+func (h *SSEHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan Event, 16)
+	h.mu.Lock()
+	h.clients[ch] = struct{}{}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, ch)
+		h.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			body, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, body)
+			flusher.Flush()
+		}
+	}
+}
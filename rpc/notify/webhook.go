@@ -0,0 +1,45 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookHandler returns a Handler that POSTs each event as JSON to url. It
+// is intended for operators who want to trigger external accounting or
+// alerting systems when a managed ticket votes, misses, or is revoked.
+// Delivery is best-effort and happens on its own goroutine, since Bus
+// dispatches to subscribers synchronously and a webhook POST must not block
+// the dispatch loop (and every other subscriber) for up to the client
+// timeout; failures are reported to logErr.
+func WebhookHandler(url string, logErr func(error)) Handler {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	return func(e Event) {
+		go func() {
+			body, err := json.Marshal(e)
+			if err != nil {
+				logErr(fmt.Errorf("notify: marshal event for webhook: %w", err))
+				return
+			}
+
+			resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+			if err != nil {
+				logErr(fmt.Errorf("notify: webhook post to %s failed: %w", url, err))
+				return
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode >= 300 {
+				logErr(fmt.Errorf("notify: webhook post to %s returned status %s", url, resp.Status))
+			}
+		}()
+	}
+}
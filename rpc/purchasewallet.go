@@ -0,0 +1,143 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	wallettypes "decred.org/dcrwallet/rpc/jsonrpc/types"
+	"github.com/decred/dcrd/dcrutil/v3"
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+)
+
+const (
+	requiredPurchaseWalletVersion = "8.1.0"
+)
+
+// PurchaseWalletRPC provides methods for calling dcrwallet JSON-RPCs without
+// exposing the details of JSON encoding. It is used to drive vspd's own
+// ticket purchases from a funding wallet, as opposed to FeeWalletRPC which
+// only ever handles fee payments.
+type PurchaseWalletRPC struct {
+	Caller
+	ctx context.Context
+}
+
+// PurchaseWalletClient creates a new PurchaseWalletRPC client instance from a
+// caller.
+func PurchaseWalletClient(ctx context.Context, c Caller) (*PurchaseWalletRPC, error) {
+
+	// Verify dcrwallet is at the required api version.
+	var verMap map[string]dcrdtypes.VersionResult
+	err := c.Call(ctx, "version", &verMap)
+	if err != nil {
+		return nil, fmt.Errorf("version check failed: %v", err)
+	}
+	walletVersion, exists := verMap["dcrwalletjsonrpcapi"]
+	if !exists {
+		return nil, fmt.Errorf("version response missing 'dcrwalletjsonrpcapi'")
+	}
+	if walletVersion.VersionString != requiredPurchaseWalletVersion {
+		return nil, fmt.Errorf("wrong dcrwallet RPC version: expected %s, got %s",
+			requiredPurchaseWalletVersion, walletVersion.VersionString)
+	}
+
+	// Verify dcrwallet is connected to dcrd (not SPV), otherwise getvsp and
+	// mempool-aware purchase decisions would be unreliable.
+	var walletInfo wallettypes.WalletInfoResult
+	err = c.Call(ctx, "walletinfo", &walletInfo)
+	if err != nil {
+		return nil, fmt.Errorf("walletinfo check failed: %v", err)
+	}
+	if !walletInfo.DaemonConnected {
+		return nil, fmt.Errorf("wallet is not connected to dcrd")
+	}
+
+	return &PurchaseWalletRPC{c, ctx}, nil
+}
+
+// PurchaseTicket uses the purchaseticket RPC to buy up to numTickets tickets
+// from account, refusing to pay more than spendLimit DCR per ticket.
+func (c *PurchaseWalletRPC) PurchaseTicket(account string, spendLimit dcrutil.Amount, minConf int32, numTickets int) ([]string, error) {
+	var hashes []string
+	err := c.Call(c.ctx, "purchaseticket", &hashes, account, spendLimit.ToCoin(), minConf, nil, numTickets)
+	if err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func (c *PurchaseWalletRPC) GetTicketFee() (dcrutil.Amount, error) {
+	var amount dcrutil.Amount
+	var feeF float64
+	err := c.Call(c.ctx, "getticketfee", &feeF)
+	if err != nil {
+		return amount, err
+	}
+
+	amount, err = dcrutil.NewAmount(feeF)
+	if err != nil {
+		return amount, err
+	}
+
+	return amount, nil
+}
+
+func (c *PurchaseWalletRPC) SignRawTransaction(txHex string) (string, error) {
+	var resp wallettypes.SignRawTransactionResult
+	err := c.Call(c.ctx, "signrawtransaction", &resp, txHex)
+	if err != nil {
+		return "", err
+	}
+	return resp.Hex, nil
+}
+
+func (c *PurchaseWalletRPC) GetNewAddress(account string) (string, error) {
+	var addr string
+	err := c.Call(c.ctx, "getnewaddress", &addr, account)
+	if err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+func (c *PurchaseWalletRPC) AccountBalance(account string, minConf int32) (dcrutil.Amount, error) {
+	var amount dcrutil.Amount
+	var resp wallettypes.GetAccountBalanceResult
+	err := c.Call(c.ctx, "getbalance", &resp, account, minConf)
+	if err != nil {
+		return amount, err
+	}
+
+	amount, err = dcrutil.NewAmount(resp.Spendable)
+	if err != nil {
+		return amount, err
+	}
+
+	return amount, nil
+}
+
+func (c *PurchaseWalletRPC) ListUnspent(account string) ([]wallettypes.ListUnspentResult, error) {
+	var resp []wallettypes.ListUnspentResult
+	err := c.Call(c.ctx, "listunspent", &resp, 1, 9999999, nil, account)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StakeInfo uses the getstakeinfo RPC to retrieve the wallet's current
+// mempool, immature, and live ticket counts, so the ticket buyer can tell how
+// many of its own tickets are still outstanding and how crowded the sstx
+// mempool is.
+func (c *PurchaseWalletRPC) StakeInfo() (*wallettypes.GetStakeInfoResult, error) {
+	var info wallettypes.GetStakeInfoResult
+	err := c.Call(c.ctx, "getstakeinfo", &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
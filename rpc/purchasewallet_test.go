@@ -0,0 +1,125 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	wallettypes "decred.org/dcrwallet/rpc/jsonrpc/types"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// purchaseWalletStubCaller is a stub Caller answering each dcrwallet RPC used
+// by PurchaseWalletRPC with a canned result.
+type purchaseWalletStubCaller struct{}
+
+func (s purchaseWalletStubCaller) Call(ctx context.Context, method string, res interface{}, args ...interface{}) error {
+	switch method {
+	case "purchaseticket":
+		*res.(*[]string) = []string{"tickethash1"}
+	case "getticketfee":
+		*res.(*float64) = 0.01
+	case "signrawtransaction":
+		*res.(*wallettypes.SignRawTransactionResult) = wallettypes.SignRawTransactionResult{Hex: "deadbeef"}
+	case "getnewaddress":
+		*res.(*string) = "Dsaddress"
+	case "getbalance":
+		*res.(*wallettypes.GetAccountBalanceResult) = wallettypes.GetAccountBalanceResult{Spendable: 5}
+	case "listunspent":
+		*res.(*[]wallettypes.ListUnspentResult) = []wallettypes.ListUnspentResult{{}, {}}
+	case "getstakeinfo":
+		*res.(*wallettypes.GetStakeInfoResult) = wallettypes.GetStakeInfoResult{
+			OwnMempoolTix: 1,
+			Immature:      2,
+			Live:          3,
+			AllMempoolTix: 10,
+		}
+	}
+	return nil
+}
+
+func newTestPurchaseWalletRPC() *PurchaseWalletRPC {
+	return &PurchaseWalletRPC{Caller: purchaseWalletStubCaller{}, ctx: context.Background()}
+}
+
+func TestPurchaseTicket(t *testing.T) {
+	c := newTestPurchaseWalletRPC()
+	hashes, err := c.PurchaseTicket("default", 10, 1, 1)
+	if err != nil {
+		t.Fatalf("PurchaseTicket() returned error: %v", err)
+	}
+	if len(hashes) != 1 || hashes[0] != "tickethash1" {
+		t.Fatalf("PurchaseTicket() = %v, want [tickethash1]", hashes)
+	}
+}
+
+func TestGetTicketFee(t *testing.T) {
+	c := newTestPurchaseWalletRPC()
+	fee, err := c.GetTicketFee()
+	if err != nil {
+		t.Fatalf("GetTicketFee() returned error: %v", err)
+	}
+	want, _ := dcrutil.NewAmount(0.01)
+	if fee != want {
+		t.Fatalf("GetTicketFee() = %v, want %v", fee, want)
+	}
+}
+
+func TestSignRawTransaction(t *testing.T) {
+	c := newTestPurchaseWalletRPC()
+	hex, err := c.SignRawTransaction("abcd")
+	if err != nil {
+		t.Fatalf("SignRawTransaction() returned error: %v", err)
+	}
+	if hex != "deadbeef" {
+		t.Fatalf("SignRawTransaction() = %s, want deadbeef", hex)
+	}
+}
+
+func TestGetNewAddress(t *testing.T) {
+	c := newTestPurchaseWalletRPC()
+	addr, err := c.GetNewAddress("default")
+	if err != nil {
+		t.Fatalf("GetNewAddress() returned error: %v", err)
+	}
+	if addr != "Dsaddress" {
+		t.Fatalf("GetNewAddress() = %s, want Dsaddress", addr)
+	}
+}
+
+func TestAccountBalance(t *testing.T) {
+	c := newTestPurchaseWalletRPC()
+	bal, err := c.AccountBalance("default", 1)
+	if err != nil {
+		t.Fatalf("AccountBalance() returned error: %v", err)
+	}
+	want, _ := dcrutil.NewAmount(5)
+	if bal != want {
+		t.Fatalf("AccountBalance() = %v, want %v", bal, want)
+	}
+}
+
+func TestListUnspent(t *testing.T) {
+	c := newTestPurchaseWalletRPC()
+	unspent, err := c.ListUnspent("default")
+	if err != nil {
+		t.Fatalf("ListUnspent() returned error: %v", err)
+	}
+	if len(unspent) != 2 {
+		t.Fatalf("ListUnspent() returned %d entries, want 2", len(unspent))
+	}
+}
+
+func TestStakeInfo(t *testing.T) {
+	c := newTestPurchaseWalletRPC()
+	info, err := c.StakeInfo()
+	if err != nil {
+		t.Fatalf("StakeInfo() returned error: %v", err)
+	}
+	if info.OwnMempoolTix != 1 || info.Immature != 2 || info.Live != 3 || info.AllMempoolTix != 10 {
+		t.Fatalf("StakeInfo() = %+v, want OwnMempoolTix=1 Immature=2 Live=3 AllMempoolTix=10", info)
+	}
+}
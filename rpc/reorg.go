@@ -0,0 +1,192 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/decred/vspd/rpc/notify"
+	"github.com/jrick/wsrpc/v2"
+)
+
+// feeTxRecord tracks the last known confirmation state of a fee transaction,
+// so a reorg that unconfirms it can be detected. txHex is kept so the fee tx
+// can still be rebroadcast if it falls out of both the chain and the mempool
+// entirely, in which case dcrd has nothing left to hand back for us to
+// rebroadcast from.
+type feeTxRecord struct {
+	ticketHash string
+	txHash     string
+	txHex      string
+	blockHash  string
+	height     int64
+}
+
+// ReorgMonitor defends vspd's fee transaction bookkeeping against reorgs by
+// re-verifying that tracked fee transactions are still confirmed on the main
+// chain whenever dcrd reports a disconnected block, rebroadcasting any that
+// fell out of the chain.
+type ReorgMonitor struct {
+	dcrd *DcrdRPC
+
+	// window is how many blocks of history are kept; fee transactions
+	// recorded at a height more than window blocks below the tip are assumed
+	// settled and are dropped from tracking.
+	window int64
+
+	mu      sync.Mutex
+	records map[string]feeTxRecord // keyed by ticket hash
+}
+
+// NewReorgMonitor creates a ReorgMonitor that re-verifies fee transactions
+// confirmed within the last window blocks.
+func NewReorgMonitor(dcrd *DcrdRPC, window int64) *ReorgMonitor {
+	return &ReorgMonitor{
+		dcrd:    dcrd,
+		window:  window,
+		records: make(map[string]feeTxRecord),
+	}
+}
+
+// Track records that a ticket's fee transaction txHash (with raw serialized
+// form txHex, for rebroadcasting later) confirmed in blockHash at the given
+// height, so the ReorgMonitor can detect if that block later falls out of the
+// main chain.
+func (m *ReorgMonitor) Track(ticketHash, txHash, txHex, blockHash string, height int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[ticketHash] = feeTxRecord{
+		ticketHash: ticketHash,
+		txHash:     txHash,
+		txHex:      txHex,
+		blockHash:  blockHash,
+		height:     height,
+	}
+}
+
+// Untrack stops tracking a ticket's fee transaction, for example once the
+// ticket has voted, been revoked, or aged out of the reorg window.
+func (m *ReorgMonitor) Untrack(ticketHash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, ticketHash)
+}
+
+// tracked returns a snapshot of the records within the current window,
+// relative to tipHeight, dropping (and forgetting) any that have aged out.
+func (m *ReorgMonitor) tracked(tipHeight int64) []feeTxRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tracked := make([]feeTxRecord, 0, len(m.records))
+	for ticketHash, rec := range m.records {
+		if tipHeight-rec.height > m.window {
+			delete(m.records, ticketHash)
+			continue
+		}
+		tracked = append(tracked, rec)
+	}
+	return tracked
+}
+
+// OnBlockDisconnected should be called for every blockdisconnected
+// notification received from dcrd. It re-queries getrawtransaction for every
+// fee tx recorded within the last window blocks and rebroadcasts any whose
+// confirming block no longer matches the main chain.
+func (m *ReorgMonitor) OnBlockDisconnected(tipHeight int64) error {
+	return m.reconcile(m.tracked(tipHeight))
+}
+
+// Subscribe registers the ReorgMonitor on bus so OnBlockDisconnected runs
+// automatically for every BlockDisconnected event, rather than requiring the
+// caller to wire dcrd's blockdisconnected notifications in by hand. Errors
+// from OnBlockDisconnected are reported to logErr, since Bus handlers cannot
+// fail dispatch.
+func (m *ReorgMonitor) Subscribe(bus *notify.Bus, logErr func(error)) {
+	bus.Subscribe(notify.BlockDisconnected, func(e notify.Event) {
+		if e.Block == nil {
+			return
+		}
+		if err := m.OnBlockDisconnected(e.Block.BlockHeight); err != nil {
+			logErr(err)
+		}
+	})
+}
+
+// ReconcileAfterReorg re-verifies every tracked fee transaction against the
+// chain tipped at tipHash. It is intended to be run once on startup to catch
+// reorgs that happened while vspd was offline, since those wouldn't have
+// produced a blockdisconnected notification for this process to observe.
+func (m *ReorgMonitor) ReconcileAfterReorg(ctx context.Context, tipHash string) error {
+	verbose := true
+	var header dcrdtypes.GetBlockHeaderVerboseResult
+	err := m.dcrd.Call(ctx, "getblockheader", &header, tipHash, verbose)
+	if err != nil {
+		return fmt.Errorf("reorgmonitor: getblockheader for tip %s failed: %w", tipHash, err)
+	}
+
+	return m.reconcile(m.tracked(int64(header.Height)))
+}
+
+// reconcile re-verifies the given fee transaction records, rebroadcasting
+// any whose confirming block hash no longer matches the main chain.
+func (m *ReorgMonitor) reconcile(records []feeTxRecord) error {
+	var errs []error
+	for _, rec := range records {
+		rawTx, err := m.dcrd.GetRawTransaction(rec.txHash)
+		if err != nil {
+			// needs-rebroadcast: the fee tx has fallen out of both the chain
+			// and the mempool entirely, so there's no confirming block left
+			// to compare against and dcrd has nothing to hand back. This is
+			// the worst case the monitor exists to catch, so rebroadcast
+			// directly from the recorded raw tx instead of giving up.
+			var rpcErr *wsrpc.Error
+			if errors.As(err, &rpcErr) && rpcErr.Code == ErrNoTxInfo {
+				if err := m.dcrd.SendRawTransaction(rec.txHex); err != nil {
+					errs = append(errs, fmt.Errorf("reorgmonitor: rebroadcast of %s for ticket %s failed: %w",
+						rec.txHash, rec.ticketHash, err))
+					continue
+				}
+				// The tx is unconfirmed again until it's mined, so clear
+				// blockHash rather than leaving the stale one behind -
+				// otherwise this record would look mismatched again (and get
+				// rebroadcast again) on every reorg notification until it
+				// ages out of the window.
+				m.Track(rec.ticketHash, rec.txHash, rec.txHex, "", rec.height)
+				continue
+			}
+
+			errs = append(errs, fmt.Errorf("reorgmonitor: getrawtransaction for %s failed: %w", rec.txHash, err))
+			continue
+		}
+
+		// Still confirmed in the block it was last seen in; nothing to do.
+		if rawTx.BlockHash == rec.blockHash {
+			continue
+		}
+
+		// needs-rebroadcast: the fee tx is unconfirmed, or confirmed in a
+		// different block than previously recorded, so send it again.
+		if err := m.dcrd.SendRawTransaction(rawTx.Hex); err != nil {
+			errs = append(errs, fmt.Errorf("reorgmonitor: rebroadcast of %s for ticket %s failed: %w",
+				rec.txHash, rec.ticketHash, err))
+			continue
+		}
+
+		// Record the new confirming block (or its absence) so this drift
+		// isn't rediscovered on every later reorg notification within the
+		// tracking window.
+		m.Track(rec.ticketHash, rec.txHash, rawTx.Hex, rawTx.BlockHash, rec.height)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("reorgmonitor: %d fee transaction(s) failed reconciliation: %v", len(errs), errs[0])
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/jrick/wsrpc/v2"
+)
+
+// reconcileCaller is a stub Caller answering getrawtransaction and
+// sendrawtransaction for reconcile() tests.
+type reconcileCaller struct {
+	mu        sync.Mutex
+	txResults map[string]dcrdtypes.TxRawResult
+	txErrs    map[string]error
+	sent      []string
+}
+
+func (s *reconcileCaller) Call(ctx context.Context, method string, res interface{}, args ...interface{}) error {
+	switch method {
+	case "getrawtransaction":
+		hash := args[0].(string)
+		if err, ok := s.txErrs[hash]; ok {
+			return err
+		}
+		tx, ok := s.txResults[hash]
+		if !ok {
+			return fmt.Errorf("no result configured for %s", hash)
+		}
+		*res.(*dcrdtypes.TxRawResult) = tx
+		return nil
+	case "sendrawtransaction":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.sent = append(s.sent, args[0].(string))
+		return nil
+	}
+	return nil
+}
+
+func newReconcileMonitor(caller *reconcileCaller) *ReorgMonitor {
+	dcrd := &DcrdRPC{Caller: caller, ctx: context.Background()}
+	return NewReorgMonitor(dcrd, 10)
+}
+
+func TestReconcileStillConfirmedDoesNotRebroadcast(t *testing.T) {
+	caller := &reconcileCaller{
+		txResults: map[string]dcrdtypes.TxRawResult{
+			"feetx1": {BlockHash: "blockA", Hex: "aabb"},
+		},
+	}
+	m := newReconcileMonitor(caller)
+	m.Track("ticket1", "feetx1", "aabb", "blockA", 100)
+
+	if err := m.OnBlockDisconnected(105); err != nil {
+		t.Fatalf("OnBlockDisconnected() returned error: %v", err)
+	}
+	if len(caller.sent) != 0 {
+		t.Fatalf("sendrawtransaction called %d times, want 0", len(caller.sent))
+	}
+}
+
+func TestReconcileReorgedBlockRebroadcastsFromGetRawTransaction(t *testing.T) {
+	caller := &reconcileCaller{
+		txResults: map[string]dcrdtypes.TxRawResult{
+			"feetx1": {BlockHash: "blockB", Hex: "ccdd"},
+		},
+	}
+	m := newReconcileMonitor(caller)
+	m.Track("ticket1", "feetx1", "aabb", "blockA", 100)
+
+	if err := m.OnBlockDisconnected(105); err != nil {
+		t.Fatalf("OnBlockDisconnected() returned error: %v", err)
+	}
+	if len(caller.sent) != 1 || caller.sent[0] != "ccdd" {
+		t.Fatalf("sent = %v, want [ccdd]", caller.sent)
+	}
+}
+
+func TestReconcileMissingTxRebroadcastsFromStoredHex(t *testing.T) {
+	caller := &reconcileCaller{
+		txErrs: map[string]error{
+			"feetx1": &wsrpc.Error{Code: ErrNoTxInfo},
+		},
+	}
+	m := newReconcileMonitor(caller)
+	m.Track("ticket1", "feetx1", "deadbeef", "blockA", 100)
+
+	if err := m.OnBlockDisconnected(105); err != nil {
+		t.Fatalf("OnBlockDisconnected() returned error: %v", err)
+	}
+	if len(caller.sent) != 1 || caller.sent[0] != "deadbeef" {
+		t.Fatalf("sent = %v, want [deadbeef]", caller.sent)
+	}
+}
+
+func TestReconcileAgedOutRecordIsDropped(t *testing.T) {
+	caller := &reconcileCaller{}
+	m := newReconcileMonitor(caller)
+	m.Track("ticket1", "feetx1", "aabb", "blockA", 100)
+
+	// tipHeight - height (100) exceeds the window (10), so the record
+	// should be forgotten without ever calling getrawtransaction.
+	if err := m.OnBlockDisconnected(200); err != nil {
+		t.Fatalf("OnBlockDisconnected() returned error: %v", err)
+	}
+	if len(caller.sent) != 0 {
+		t.Fatalf("sendrawtransaction called %d times, want 0", len(caller.sent))
+	}
+	if len(m.tracked(200)) != 0 {
+		t.Fatalf("expected aged out record to be dropped from tracking")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	wallettypes "decred.org/dcrwallet/rpc/jsonrpc/types"
+	"github.com/decred/dcrd/chaincfg/v3"
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/decred/dcrd/wire"
+	"github.com/jrick/wsrpc/v2"
+)
+
+var (
+	requiredSPVWalletVersion = semver{Major: 8, Minor: 1, Patch: 0}
+)
+
+// stakeSubmissionOutput is the index of a ticket's stake submission output,
+// used when falling back to gettxout to determine liveness.
+const stakeSubmissionOutput = 0
+
+// SPVClient provides methods for calling dcrwallet JSON-RPCs without exposing
+// the details of JSON encoding. It is backed by a dcrwallet running in SPV
+// mode and implements the same surface as DcrdRPC, allowing vspd to operate
+// without a full dcrd node.
+type SPVClient struct {
+	Caller
+	ctx context.Context
+
+	mu      sync.Mutex
+	watched map[string]struct{}
+}
+
+type SPVConnect struct {
+	*client
+}
+
+// SetupSPV initializes a connection to a dcrwallet running in SPV mode.
+func SetupSPV(user, pass, addr string, cert []byte, n wsrpc.Notifier) SPVConnect {
+	return SPVConnect{setup(user, pass, addr, cert, n)}
+}
+
+// Client creates a new SPVClient instance. Returns an error if dialing
+// dcrwallet fails or if dcrwallet is misconfigured.
+func (s *SPVConnect) Client(ctx context.Context, netParams *chaincfg.Params) (*SPVClient, error) {
+	c, newConnection, err := s.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dcrwallet connection error: %w", err)
+	}
+
+	// If this is a reused connection, we don't need to validate the
+	// dcrwallet config again.
+	if !newConnection {
+		return &SPVClient{Caller: c, ctx: ctx, watched: make(map[string]struct{})}, nil
+	}
+
+	// Verify dcrwallet is at the required api version.
+	var verMap map[string]dcrdtypes.VersionResult
+	err = c.Call(ctx, "version", &verMap)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("dcrwallet version check failed: %w", err)
+	}
+
+	ver, exists := verMap["dcrwalletjsonrpcapi"]
+	if !exists {
+		s.Close()
+		return nil, fmt.Errorf("dcrwallet version response missing 'dcrwalletjsonrpcapi'")
+	}
+
+	sVer := semver{ver.Major, ver.Minor, ver.Patch}
+	if !semverCompatible(requiredSPVWalletVersion, sVer) {
+		s.Close()
+		return nil, fmt.Errorf("dcrwallet has incompatible JSON-RPC version: got %s, expected %s",
+			sVer, requiredSPVWalletVersion)
+	}
+
+	// Verify dcrwallet is running in SPV mode.
+	var walletInfo wallettypes.WalletInfoResult
+	err = c.Call(ctx, "walletinfo", &walletInfo)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("dcrwallet walletinfo check failed: %w", err)
+	}
+	if !walletInfo.SPV {
+		s.Close()
+		return nil, errors.New("dcrwallet is not running in SPV mode, use DcrdConnect instead")
+	}
+
+	return &SPVClient{Caller: c, ctx: ctx, watched: make(map[string]struct{})}, nil
+}
+
+// GetRawTransaction uses the getrawtransaction RPC to retrieve details about
+// the transaction with the provided hash. In SPV mode dcrwallet can only
+// answer this for transactions relevant to the wallet or recovered through
+// its header-filter rescan, so the hash is registered with WatchTicket first
+// - otherwise vspd's tickets, which are submitted by arbitrary external
+// stakers, would return ErrNoTxInfo here just like gettxout would.
+func (c *SPVClient) GetRawTransaction(txHash string) (*dcrdtypes.TxRawResult, error) {
+	if err := c.WatchTicket(txHash); err != nil {
+		return nil, err
+	}
+
+	verbose := 1
+	var resp dcrdtypes.TxRawResult
+	err := c.Call(c.ctx, "getrawtransaction", &resp, txHash, verbose)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SendRawTransaction uses the sendrawtransaction RPC to broadcast a
+// transaction to the network. It ignores errors caused by duplicate
+// transactions.
+func (c *SPVClient) SendRawTransaction(txHex string) error {
+	allowHighFees := false
+	err := c.Call(c.ctx, "sendrawtransaction", nil, txHex, allowHighFees)
+	if err != nil {
+		var e *wsrpc.Error
+		if errors.As(err, &e) && e.Code == ErrRPCDuplicateTx {
+			return nil
+		}
+		if strings.Contains(err.Error(), "transaction already exists") {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// NotifyBlocks uses the notifyblocks RPC to request new block notifications
+// from dcrwallet.
+func (c *SPVClient) NotifyBlocks() error {
+	return c.Call(c.ctx, "notifyblocks", nil)
+}
+
+// GetBestBlockHeader uses the getbestblockhash RPC, followed by the
+// getblockheader RPC, to retrieve the header of the best block known to the
+// dcrwallet instance.
+func (c *SPVClient) GetBestBlockHeader() (*dcrdtypes.GetBlockHeaderVerboseResult, error) {
+	var bestBlockHash string
+	err := c.Call(c.ctx, "getbestblockhash", &bestBlockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	verbose := true
+	var blockHeader dcrdtypes.GetBlockHeaderVerboseResult
+	err = c.Call(c.ctx, "getblockheader", &blockHeader, bestBlockHash, verbose)
+	if err != nil {
+		return nil, err
+	}
+	return &blockHeader, nil
+}
+
+// WatchTicket registers a ticket's stake submission output with dcrwallet's
+// compact filter via loadtxfilter, so an SPV wallet that does not otherwise
+// own the ticket can still resolve it with gettxout. vspd manages tickets
+// submitted by arbitrary external stakers, not just wallet-owned outputs, so
+// without this the gettxout fallback in ExistsLiveTicket would report
+// "not found" for the majority of managed tickets. Registering the same
+// ticket hash more than once is a no-op.
+func (c *SPVClient) WatchTicket(ticketHash string) error {
+	c.mu.Lock()
+	_, already := c.watched[ticketHash]
+	c.mu.Unlock()
+	if already {
+		return nil
+	}
+
+	reload := false
+	outpoints := []wallettypes.OutPoint{
+		{Hash: ticketHash, Index: stakeSubmissionOutput, Tree: wire.TxTreeStake},
+	}
+	err := c.Call(c.ctx, "loadtxfilter", nil, reload, []string{}, outpoints)
+	if err != nil {
+		return fmt.Errorf("loadtxfilter for ticket %s failed: %w", ticketHash, err)
+	}
+
+	c.mu.Lock()
+	c.watched[ticketHash] = struct{}{}
+	c.mu.Unlock()
+	return nil
+}
+
+// ExistsLiveTicket checks if the provided ticket hash is a live ticket. A
+// dcrwallet in SPV mode has no access to dcrd's live ticket bitset, so this
+// falls back to gettxout on the ticket's stake submission output: an unspent
+// output there means the ticket has not yet voted, been revoked, or expired.
+// The output is registered with WatchTicket first, since gettxout can only
+// resolve outputs the wallet's compact filter has been told to track.
+func (c *SPVClient) ExistsLiveTicket(ticketHash string) (bool, error) {
+	if err := c.WatchTicket(ticketHash); err != nil {
+		return false, err
+	}
+
+	var txOut *dcrdtypes.GetTxOutResult
+	err := c.Call(c.ctx, "gettxout", &txOut, ticketHash, stakeSubmissionOutput, true)
+	if err != nil {
+		return false, err
+	}
+
+	return txOut != nil, nil
+}
+
+// CanTicketVote checks determines whether a ticket is able to vote at some
+// point in the future by checking that it is currently either immature or
+// live.
+func (c *SPVClient) CanTicketVote(rawTx *dcrdtypes.TxRawResult, ticketHash string, netParams *chaincfg.Params) (bool, error) {
+
+	// Tickets which have more than (TicketMaturity+TicketExpiry+1)
+	// confirmations are too old to vote.
+	if rawTx.Confirmations > int64(uint32(netParams.TicketMaturity)+netParams.TicketExpiry)+1 {
+		return false, nil
+	}
+
+	// If ticket is currently immature, it will be able to vote in future.
+	if rawTx.Confirmations <= int64(netParams.TicketMaturity) {
+		return true, nil
+	}
+
+	// If ticket is currently live, it will be able to vote in future. This
+	// degrades to the gettxout fallback above rather than dcrd's
+	// existslivetickets bitset.
+	live, err := c.ExistsLiveTicket(ticketHash)
+	if err != nil {
+		return false, err
+	}
+
+	return live, nil
+}
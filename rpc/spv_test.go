@@ -0,0 +1,110 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+)
+
+// spvStubCaller is a stub Caller recording which RPCs were issued, in order,
+// and answering gettxout/getrawtransaction with canned results.
+type spvStubCaller struct {
+	calls []string
+	txOut *dcrdtypes.GetTxOutResult
+	rawTx dcrdtypes.TxRawResult
+}
+
+func (s *spvStubCaller) Call(ctx context.Context, method string, res interface{}, args ...interface{}) error {
+	s.calls = append(s.calls, method)
+	switch method {
+	case "gettxout":
+		*res.(**dcrdtypes.GetTxOutResult) = s.txOut
+	case "getrawtransaction":
+		*res.(*dcrdtypes.TxRawResult) = s.rawTx
+	}
+	return nil
+}
+
+func newTestSPVClient(caller *spvStubCaller) *SPVClient {
+	return &SPVClient{Caller: caller, ctx: context.Background(), watched: make(map[string]struct{})}
+}
+
+func TestWatchTicketRegistersOncePerHash(t *testing.T) {
+	caller := &spvStubCaller{}
+	c := newTestSPVClient(caller)
+
+	if err := c.WatchTicket("ticket1"); err != nil {
+		t.Fatalf("WatchTicket() returned error: %v", err)
+	}
+	if err := c.WatchTicket("ticket1"); err != nil {
+		t.Fatalf("WatchTicket() returned error: %v", err)
+	}
+
+	if len(caller.calls) != 1 {
+		t.Fatalf("loadtxfilter called %d times, want 1; calls=%v", len(caller.calls), caller.calls)
+	}
+}
+
+func TestExistsLiveTicketRegistersOutpointFirst(t *testing.T) {
+	caller := &spvStubCaller{txOut: &dcrdtypes.GetTxOutResult{Confirmations: 1}}
+	c := newTestSPVClient(caller)
+
+	live, err := c.ExistsLiveTicket("ticket1")
+	if err != nil {
+		t.Fatalf("ExistsLiveTicket() returned error: %v", err)
+	}
+	if !live {
+		t.Fatal("ExistsLiveTicket() = false, want true")
+	}
+
+	want := []string{"loadtxfilter", "gettxout"}
+	if len(caller.calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", caller.calls, want)
+	}
+	for i := range want {
+		if caller.calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", caller.calls, want)
+		}
+	}
+}
+
+func TestExistsLiveTicketFalseWhenSpent(t *testing.T) {
+	caller := &spvStubCaller{txOut: nil}
+	c := newTestSPVClient(caller)
+
+	live, err := c.ExistsLiveTicket("ticket1")
+	if err != nil {
+		t.Fatalf("ExistsLiveTicket() returned error: %v", err)
+	}
+	if live {
+		t.Fatal("ExistsLiveTicket() = true, want false")
+	}
+}
+
+func TestGetRawTransactionRegistersOutpointFirst(t *testing.T) {
+	caller := &spvStubCaller{rawTx: dcrdtypes.TxRawResult{Txid: "ticket1"}}
+	c := newTestSPVClient(caller)
+
+	tx, err := c.GetRawTransaction("ticket1")
+	if err != nil {
+		t.Fatalf("GetRawTransaction() returned error: %v", err)
+	}
+	if tx.Txid != "ticket1" {
+		t.Fatalf("Txid = %s, want ticket1", tx.Txid)
+	}
+
+	want := []string{"loadtxfilter", "getrawtransaction"}
+	if len(caller.calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", caller.calls, want)
+	}
+	for i := range want {
+		if caller.calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", caller.calls, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package ticketbuyer drives automatic ticket purchases from a funding
+// wallet so vspd can buy tickets that register themselves against its own
+// VSP, rather than relying solely on tickets submitted by external clients.
+package ticketbuyer
+
+import (
+	"context"
+	"fmt"
+
+	wallettypes "decred.org/dcrwallet/rpc/jsonrpc/types"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/dcrutil/v3"
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+)
+
+// dcrdClient is the subset of rpc.DcrdRPC (and rpc.MultiDcrdRPC, which
+// implements the same method) that the ticket buyer needs to price a
+// purchase.
+type dcrdClient interface {
+	GetBestBlockHeader() (*dcrdtypes.GetBlockHeaderVerboseResult, error)
+}
+
+// walletClient is the subset of rpc.PurchaseWalletRPC that the ticket buyer
+// needs to check outstanding tickets and submit purchases.
+type walletClient interface {
+	StakeInfo() (*wallettypes.GetStakeInfoResult, error)
+	PurchaseTicket(account string, spendLimit dcrutil.Amount, minConf int32, numTickets int) ([]string, error)
+}
+
+// Config holds the parameters that control how the ticket buyer purchases
+// tickets from the funding wallet.
+type Config struct {
+	// FundingAccount is the dcrwallet account tickets are purchased from.
+	FundingAccount string
+	// TargetTicketCount is the number of live tickets the buyer tries to
+	// maintain at any given time.
+	TargetTicketCount int
+	// MaxPriceAbsolute is the highest ticket price, in DCR, the buyer is
+	// willing to pay. Purchases are skipped while the ticket price exceeds
+	// this limit.
+	MaxPriceAbsolute dcrutil.Amount
+	// MaxPerBlock limits how many tickets the buyer will purchase in a
+	// single block, to avoid flooding the mempool with sstx.
+	MaxPerBlock int
+	// MaxMempoolTix caps how many sstx may already be sitting in the
+	// mempool (from any wallet) before the buyer holds off on purchasing
+	// more this block. Zero means no limit.
+	MaxMempoolTix uint32
+}
+
+// Buyer purchases tickets from a funding wallet and registers them against
+// vspd's own VSP.
+type Buyer struct {
+	cfg       Config
+	wallet    walletClient
+	dcrd      dcrdClient
+	netParams *chaincfg.Params
+}
+
+// NewBuyer creates a Buyer that purchases tickets using the provided funding
+// wallet and dcrd connections.
+func NewBuyer(cfg Config, wallet walletClient, dcrd dcrdClient, netParams *chaincfg.Params) *Buyer {
+	return &Buyer{
+		cfg:       cfg,
+		wallet:    wallet,
+		dcrd:      dcrd,
+		netParams: netParams,
+	}
+}
+
+// MaybeBuy purchases tickets, if any, that should be bought for the current
+// block according to the configured target ticket count, price limit,
+// mempool sstx cap, and per-block cap. It is intended to be called once per
+// connected block.
+func (b *Buyer) MaybeBuy(ctx context.Context) ([]string, error) {
+	header, err := b.dcrd.GetBestBlockHeader()
+	if err != nil {
+		return nil, fmt.Errorf("getbestblockheader failed: %w", err)
+	}
+
+	ticketPrice, err := dcrutil.NewAmount(header.SBits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ticket price: %w", err)
+	}
+	if b.cfg.MaxPriceAbsolute > 0 && ticketPrice > b.cfg.MaxPriceAbsolute {
+		return nil, nil
+	}
+
+	info, err := b.wallet.StakeInfo()
+	if err != nil {
+		return nil, fmt.Errorf("getstakeinfo failed: %w", err)
+	}
+	if b.cfg.MaxMempoolTix > 0 && info.AllMempoolTix >= b.cfg.MaxMempoolTix {
+		return nil, nil
+	}
+
+	// OwnMempoolTix, Immature, and Live together make up the tickets already
+	// bought that haven't voted, missed, expired, or been revoked yet.
+	have := int(info.OwnMempoolTix + info.Immature + info.Live)
+	want := b.cfg.TargetTicketCount - have
+	if want <= 0 {
+		return nil, nil
+	}
+	if want > b.cfg.MaxPerBlock {
+		want = b.cfg.MaxPerBlock
+	}
+
+	// Fall back to the current ticket price when no absolute cap is
+	// configured; otherwise enforce the operator's configured cap as the
+	// wallet-side spend limit, rather than the price that's about to be
+	// paid anyway.
+	spendLimit := ticketPrice
+	if b.cfg.MaxPriceAbsolute > 0 {
+		spendLimit = b.cfg.MaxPriceAbsolute
+	}
+
+	hashes, err := b.wallet.PurchaseTicket(b.cfg.FundingAccount, spendLimit, 1, want)
+	if err != nil {
+		return nil, fmt.Errorf("purchaseticket failed: %w", err)
+	}
+
+	return hashes, nil
+}
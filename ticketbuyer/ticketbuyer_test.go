@@ -0,0 +1,158 @@
+// Copyright (c) 2020 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ticketbuyer
+
+import (
+	"context"
+	"testing"
+
+	wallettypes "decred.org/dcrwallet/rpc/jsonrpc/types"
+	"github.com/decred/dcrd/dcrutil/v3"
+	dcrdtypes "github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+)
+
+// fakeDcrdClient is a fake dcrdClient that returns a fixed ticket price.
+type fakeDcrdClient struct {
+	sbits float64
+}
+
+func (f fakeDcrdClient) GetBestBlockHeader() (*dcrdtypes.GetBlockHeaderVerboseResult, error) {
+	return &dcrdtypes.GetBlockHeaderVerboseResult{SBits: f.sbits}, nil
+}
+
+// fakeWalletClient is a fake walletClient recording the spend limit and
+// ticket count passed to PurchaseTicket.
+type fakeWalletClient struct {
+	info *wallettypes.GetStakeInfoResult
+
+	purchased  bool
+	spendLimit dcrutil.Amount
+	numTickets int
+}
+
+func (f *fakeWalletClient) StakeInfo() (*wallettypes.GetStakeInfoResult, error) {
+	return f.info, nil
+}
+
+func (f *fakeWalletClient) PurchaseTicket(account string, spendLimit dcrutil.Amount, minConf int32, numTickets int) ([]string, error) {
+	f.purchased = true
+	f.spendLimit = spendLimit
+	f.numTickets = numTickets
+
+	hashes := make([]string, numTickets)
+	for i := range hashes {
+		hashes[i] = "tickethash"
+	}
+	return hashes, nil
+}
+
+func TestMaybeBuyPurchasesUpToTarget(t *testing.T) {
+	wallet := &fakeWalletClient{info: &wallettypes.GetStakeInfoResult{OwnMempoolTix: 1, Immature: 1, Live: 1}}
+	dcrd := fakeDcrdClient{sbits: 200}
+	cfg := Config{FundingAccount: "default", TargetTicketCount: 5, MaxPerBlock: 10}
+	b := NewBuyer(cfg, wallet, dcrd, nil)
+
+	hashes, err := b.MaybeBuy(context.Background())
+	if err != nil {
+		t.Fatalf("MaybeBuy() returned error: %v", err)
+	}
+	if !wallet.purchased {
+		t.Fatal("expected PurchaseTicket to be called")
+	}
+	// have = 1 + 1 + 1 = 3, want = target(5) - have(3) = 2.
+	if wallet.numTickets != 2 {
+		t.Fatalf("numTickets = %d, want 2", wallet.numTickets)
+	}
+	if len(hashes) != 2 {
+		t.Fatalf("MaybeBuy() returned %d hashes, want 2", len(hashes))
+	}
+}
+
+func TestMaybeBuySkipsWhenTargetAlreadyMet(t *testing.T) {
+	wallet := &fakeWalletClient{info: &wallettypes.GetStakeInfoResult{Live: 5}}
+	dcrd := fakeDcrdClient{sbits: 200}
+	cfg := Config{FundingAccount: "default", TargetTicketCount: 5, MaxPerBlock: 10}
+	b := NewBuyer(cfg, wallet, dcrd, nil)
+
+	hashes, err := b.MaybeBuy(context.Background())
+	if err != nil {
+		t.Fatalf("MaybeBuy() returned error: %v", err)
+	}
+	if wallet.purchased {
+		t.Fatal("expected PurchaseTicket not to be called")
+	}
+	if hashes != nil {
+		t.Fatalf("MaybeBuy() = %v, want nil", hashes)
+	}
+}
+
+func TestMaybeBuyCapsPurchaseAtMaxPerBlock(t *testing.T) {
+	wallet := &fakeWalletClient{info: &wallettypes.GetStakeInfoResult{}}
+	dcrd := fakeDcrdClient{sbits: 200}
+	cfg := Config{FundingAccount: "default", TargetTicketCount: 100, MaxPerBlock: 3}
+	b := NewBuyer(cfg, wallet, dcrd, nil)
+
+	if _, err := b.MaybeBuy(context.Background()); err != nil {
+		t.Fatalf("MaybeBuy() returned error: %v", err)
+	}
+	if wallet.numTickets != 3 {
+		t.Fatalf("numTickets = %d, want 3 (MaxPerBlock cap)", wallet.numTickets)
+	}
+}
+
+func TestMaybeBuySkipsWhenPriceExceedsCap(t *testing.T) {
+	wallet := &fakeWalletClient{info: &wallettypes.GetStakeInfoResult{}}
+	dcrd := fakeDcrdClient{sbits: 200}
+	maxPrice, _ := dcrutil.NewAmount(100)
+	cfg := Config{FundingAccount: "default", TargetTicketCount: 5, MaxPerBlock: 10, MaxPriceAbsolute: maxPrice}
+	b := NewBuyer(cfg, wallet, dcrd, nil)
+
+	hashes, err := b.MaybeBuy(context.Background())
+	if err != nil {
+		t.Fatalf("MaybeBuy() returned error: %v", err)
+	}
+	if wallet.purchased {
+		t.Fatal("expected PurchaseTicket not to be called when price exceeds cap")
+	}
+	if hashes != nil {
+		t.Fatalf("MaybeBuy() = %v, want nil", hashes)
+	}
+}
+
+func TestMaybeBuyEnforcesConfiguredSpendLimit(t *testing.T) {
+	wallet := &fakeWalletClient{info: &wallettypes.GetStakeInfoResult{}}
+	// A ticket price of 50 DCR (dcrutil.NewAmount(SBits)) is under the
+	// configured cap of 100 DCR, so the purchase proceeds using the cap
+	// rather than the (lower) current price as the spend limit.
+	dcrd := fakeDcrdClient{sbits: 50}
+	maxPrice, _ := dcrutil.NewAmount(100)
+	cfg := Config{FundingAccount: "default", TargetTicketCount: 5, MaxPerBlock: 10, MaxPriceAbsolute: maxPrice}
+	b := NewBuyer(cfg, wallet, dcrd, nil)
+
+	if _, err := b.MaybeBuy(context.Background()); err != nil {
+		t.Fatalf("MaybeBuy() returned error: %v", err)
+	}
+	if wallet.spendLimit != maxPrice {
+		t.Fatalf("spendLimit = %v, want %v (configured MaxPriceAbsolute)", wallet.spendLimit, maxPrice)
+	}
+}
+
+func TestMaybeBuySkipsWhenMempoolIsFull(t *testing.T) {
+	wallet := &fakeWalletClient{info: &wallettypes.GetStakeInfoResult{AllMempoolTix: 40}}
+	dcrd := fakeDcrdClient{sbits: 200}
+	cfg := Config{FundingAccount: "default", TargetTicketCount: 5, MaxPerBlock: 10, MaxMempoolTix: 20}
+	b := NewBuyer(cfg, wallet, dcrd, nil)
+
+	hashes, err := b.MaybeBuy(context.Background())
+	if err != nil {
+		t.Fatalf("MaybeBuy() returned error: %v", err)
+	}
+	if wallet.purchased {
+		t.Fatal("expected PurchaseTicket not to be called when mempool cap is exceeded")
+	}
+	if hashes != nil {
+		t.Fatalf("MaybeBuy() = %v, want nil", hashes)
+	}
+}